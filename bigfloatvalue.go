@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// bigFloatPrec is the mantissa precision, in bits, used by the "bigfloat" value
+// backend. Raise it when exact sqrt bounds are needed at high digit counts.
+var bigFloatPrec uint = 256
+
+// bigFloatValue is a Value backend backed by math/big.Float, registered as
+// "bigfloat". It supports configurable precision, which the float64 backend
+// cannot offer.
+type bigFloatValue struct {
+	f *big.Float
+}
+
+func newBigFloatFromString(s string) (Value, error) {
+	f, _, err := big.ParseFloat(s, 10, bigFloatPrec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as big.Float: %s", s, err)
+	}
+	return bigFloatValue{f}, nil
+}
+
+func init() {
+	RegisterValueBackend("bigfloat", newBigFloatFromString)
+}
+
+func newBigFloat(f *big.Float) bigFloatValue {
+	return bigFloatValue{f.SetPrec(bigFloatPrec)}
+}
+
+func (v bigFloatValue) PerformUnary(op Op) (Value, error) {
+	switch op {
+	case tagMinus:
+		return newBigFloat(new(big.Float).Neg(v.f)), nil
+	case tagSqrt:
+		if v.f.Sign() < 0 {
+			return v, fmt.Errorf("cannot take sqrt of negative number %s", v)
+		}
+		return newBigFloat(new(big.Float).Sqrt(v.f)), nil
+	case tagFact:
+		if v.Negative() || !v.IsInteger() {
+			return v, fmt.Errorf("factorial requires a non-negative integer, got %s", v)
+		}
+		n, _ := v.f.Int64()
+		r := big.NewFloat(1).SetPrec(bigFloatPrec)
+		for i := int64(2); i <= n; i++ {
+			r.Mul(r, big.NewFloat(float64(i)))
+		}
+		return bigFloatValue{r}, nil
+	default:
+		return v, fmt.Errorf("unsupported unary op %s", op)
+	}
+}
+
+func (v bigFloatValue) PerformBinary(op Op, v1 Value) (Value, error) {
+	w, ok := v1.(bigFloatValue)
+	if !ok {
+		return v, fmt.Errorf("cannot combine big.Float value with %T", v1)
+	}
+	switch op {
+	case tagAdd:
+		return newBigFloat(new(big.Float).Add(v.f, w.f)), nil
+	case tagSub:
+		return newBigFloat(new(big.Float).Sub(v.f, w.f)), nil
+	case tagMul:
+		return newBigFloat(new(big.Float).Mul(v.f, w.f)), nil
+	case tagDiv:
+		if w.f.Sign() == 0 {
+			return v, fmt.Errorf("division by zero")
+		}
+		return newBigFloat(new(big.Float).Quo(v.f, w.f)), nil
+	case tagPow:
+		return v.pow(w)
+	default:
+		return v, fmt.Errorf("unsupported binary op %s", op)
+	}
+}
+
+// pow only supports non-negative integer exponents; a negative or fractional
+// exponent returns an error.
+func (v bigFloatValue) pow(w bigFloatValue) (Value, error) {
+	if w.Negative() || !w.IsInteger() {
+		return v, fmt.Errorf("bigfloat backend only supports non-negative integer exponents, got %s", w)
+	}
+	n, _ := w.f.Int64()
+	r := big.NewFloat(1).SetPrec(bigFloatPrec)
+	for i := int64(0); i < n; i++ {
+		r.Mul(r, v.f)
+	}
+	return bigFloatValue{r}, nil
+}
+
+func (v bigFloatValue) Equal(v1 Value) bool {
+	w, ok := v1.(bigFloatValue)
+	return ok && v.f.Cmp(w.f) == 0
+}
+
+func (v bigFloatValue) Less(v1 Value) bool {
+	w, ok := v1.(bigFloatValue)
+	return ok && v.f.Cmp(w.f) < 0
+}
+
+func (v bigFloatValue) String() string {
+	return v.f.Text('g', 10)
+}
+
+func (v bigFloatValue) IsInteger() bool {
+	return v.f.IsInt()
+}
+
+func (v bigFloatValue) Negative() bool { return v.f.Sign() < 0 }
+
+func (v bigFloatValue) Even() bool {
+	if !v.IsInteger() {
+		return false
+	}
+	i, _ := v.f.Int(nil)
+	return i.Bit(0) == 0
+}
+
+func (v bigFloatValue) Zero() bool     { return v.f.Sign() == 0 }
+func (v bigFloatValue) One() bool      { return v.f.Cmp(big.NewFloat(1)) == 0 }
+func (v bigFloatValue) MinusOne() bool { return v.f.Cmp(big.NewFloat(-1)) == 0 }
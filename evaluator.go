@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by Evaluator when a formula trips one of its configured
+// limits. Callers can distinguish them with errors.Is.
+var (
+	ErrFactorialTooLarge = errors.New("factorial operand too large")
+	ErrPowTooLarge       = errors.New("exponentiation operands too large")
+	ErrTimeout           = errors.New("evaluation timed out")
+	ErrDepthExceeded     = errors.New("formula nesting too deep")
+	ErrTooManyNodes      = errors.New("formula has too many nodes")
+)
+
+// Evaluator wraps Node.Eval with bounds appropriate for accepting untrusted
+// formulas, so it is safe to embed behind a web endpoint or bot that takes
+// user-supplied digits puzzles.
+type Evaluator struct {
+	MaxDepth        int64 // max Node.Depth(); 0 means unbounded
+	MaxNodes        int   // max nodes in the formula, checked before evaluation; 0 means unbounded
+	MaxFactOperand  int64 // max |n| allowed in n!
+	MaxPowExponent  int64 // max |b| allowed in a^b
+	MaxResultDigits int64 // max projected decimal digits of a^b's result; 0 means unbounded
+}
+
+// NewEvaluator returns an Evaluator with conservative default limits.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{
+		MaxDepth:        64,
+		MaxNodes:        10000,
+		MaxFactOperand:  20,
+		MaxPowExponent:  64,
+		MaxResultDigits: 4096,
+	}
+}
+
+// Eval evaluates n, enforcing e's limits, and aborts as soon as ctx is
+// cancelled or its deadline passes.
+func (e *Evaluator) Eval(ctx context.Context, n *Node) (Value, error) {
+	if e.MaxNodes > 0 {
+		if count := countNodes(n); count > e.MaxNodes {
+			return nil, fmt.Errorf("%w: %d nodes exceeds limit of %d", ErrTooManyNodes, count, e.MaxNodes)
+		}
+	}
+	if e.MaxDepth > 0 && n.Depth() > e.MaxDepth {
+		return nil, fmt.Errorf("%w: depth %d exceeds limit of %d", ErrDepthExceeded, n.Depth(), e.MaxDepth)
+	}
+	return e.eval(ctx, n)
+}
+
+func (e *Evaluator) eval(ctx context.Context, n *Node) (Value, error) {
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %s", ErrTimeout, ctx.Err())
+	default:
+	}
+	if !n.valid() {
+		return nil, fmt.Errorf("invalid formula %s", n)
+	}
+	if n.op == nil {
+		return n.val, nil
+	}
+	left, err := e.eval(ctx, n.left)
+	if err != nil {
+		return nil, err
+	}
+	if n.op.binary() {
+		right, err := e.eval(ctx, n.right)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == OpPow {
+			if err := e.checkPow(left, right); err != nil {
+				return nil, err
+			}
+		}
+		return n.op.applyBinary(left, right)
+	}
+	if n.op == OpFact && magnitudeTooLarge(left, e.MaxFactOperand) {
+		return nil, fmt.Errorf("%w: %s exceeds %d", ErrFactorialTooLarge, left, e.MaxFactOperand)
+	}
+	return n.op.applyUnary(left)
+}
+
+// checkPow rejects an exponentiation whose exponent magnitude, or whose
+// projected result size, exceeds e's limits.
+func (e *Evaluator) checkPow(base, exp Value) error {
+	if magnitudeTooLarge(exp, e.MaxPowExponent) {
+		return fmt.Errorf("%w: exponent %s exceeds %d", ErrPowTooLarge, exp, e.MaxPowExponent)
+	}
+	if e.MaxResultDigits <= 0 || exp.Negative() || !exp.IsInteger() {
+		return nil
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(exp.String()), 10, 64)
+	if err != nil {
+		return nil
+	}
+	projected := int64(len(base.String())) * n
+	if projected > e.MaxResultDigits {
+		return fmt.Errorf("%w: %s^%s would project to roughly %d digits", ErrPowTooLarge, base, exp, projected)
+	}
+	return nil
+}
+
+// magnitudeTooLarge reports whether |v| exceeds bound, in the currently
+// selected Value backend.
+func magnitudeTooLarge(v Value, bound int64) bool {
+	if tooLarge(v, bound) {
+		return true
+	}
+	neg, err := OpMinus.applyUnary(v)
+	if err != nil {
+		return false
+	}
+	return tooLarge(neg, bound)
+}
+
+// countNodes returns the number of nodes (leaves and operators) in n.
+func countNodes(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	count := 1
+	count += countNodes(n.left)
+	count += countNodes(n.right)
+	return count
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func evalWith(t *testing.T, e *Evaluator, n *Node) (Value, error) {
+	t.Helper()
+	return e.Eval(context.Background(), n)
+}
+
+func TestEvaluatorRejectsOversizedFactorial(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxFactOperand = 5
+	n := newNode(newIntNode(6), OpFact, nil)
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrFactorialTooLarge) {
+		t.Fatalf("Eval(6!) with MaxFactOperand=5 = %v, want ErrFactorialTooLarge", err)
+	}
+	n = newNode(newIntNode(5), OpFact, nil)
+	if _, err := evalWith(t, e, n); err != nil {
+		t.Fatalf("Eval(5!) with MaxFactOperand=5 = %v, want no error", err)
+	}
+}
+
+func TestEvaluatorRejectsOversizedFactorialOfNegativeOperand(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxFactOperand = 5
+	n := newNode(newIntNode(-6), OpFact, nil)
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrFactorialTooLarge) {
+		t.Fatalf("Eval((-6)!) with MaxFactOperand=5 = %v, want ErrFactorialTooLarge", err)
+	}
+}
+
+func TestEvaluatorRejectsOversizedPowExponent(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxPowExponent = 4
+	n := newNode(newIntNode(2), OpPow, newIntNode(5))
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrPowTooLarge) {
+		t.Fatalf("Eval(2^5) with MaxPowExponent=4 = %v, want ErrPowTooLarge", err)
+	}
+}
+
+func TestEvaluatorRejectsProjectedResultTooLarge(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxPowExponent = 1000
+	e.MaxResultDigits = 10
+	n := newNode(newIntNode(2), OpPow, newIntNode(100))
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrPowTooLarge) {
+		t.Fatalf("Eval(2^100) with MaxResultDigits=10 = %v, want ErrPowTooLarge", err)
+	}
+}
+
+func TestEvaluatorRejectsExcessDepth(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxDepth = 2
+	n := newNode(newNode(newNode(newIntNode(1), OpAdd, newIntNode(2)), OpAdd, newIntNode(3)), OpAdd, newIntNode(4))
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrDepthExceeded) {
+		t.Fatalf("Eval(((1+2)+3)+4) with MaxDepth=2 = %v, want ErrDepthExceeded", err)
+	}
+}
+
+func TestEvaluatorRejectsExcessNodeCount(t *testing.T) {
+	e := NewEvaluator()
+	e.MaxDepth = 0
+	e.MaxNodes = 2
+	n := newNode(newIntNode(1), OpAdd, newIntNode(2))
+	if _, err := evalWith(t, e, n); !errors.Is(err, ErrTooManyNodes) {
+		t.Fatalf("Eval(1+2) with MaxNodes=2 = %v, want ErrTooManyNodes", err)
+	}
+}
+
+func TestEvaluatorRejectsTimeout(t *testing.T) {
+	e := NewEvaluator()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := newIntNode(1)
+	if _, err := e.Eval(ctx, n); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Eval with a cancelled context = %v, want ErrTimeout", err)
+	}
+}
+
+func TestEvaluatorAcceptsWellBoundedFormula(t *testing.T) {
+	e := NewEvaluator()
+	n := newNode(newIntNode(2), OpPow, newIntNode(10))
+	v, err := evalWith(t, e, n)
+	if err != nil {
+		t.Fatalf("Eval(2^10) = %v, want no error", err)
+	}
+	want := mustValue(t, "1024")
+	if !v.Equal(want) {
+		t.Fatalf("Eval(2^10) = %s, want 1024", v)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// floatValue is a Value backend backed by float64, registered as "float64". It
+// trades exactness for speed, which suits approximate search over many candidate
+// formulas before re-checking survivors with an exact backend.
+type floatValue float64
+
+func newFloatFromString(s string) (Value, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as float64: %s", s, err)
+	}
+	return floatValue(f), nil
+}
+
+func init() {
+	RegisterValueBackend("float64", newFloatFromString)
+}
+
+func (v floatValue) PerformUnary(op Op) (Value, error) {
+	switch op {
+	case tagMinus:
+		return -v, nil
+	case tagSqrt:
+		if v < 0 {
+			return v, fmt.Errorf("cannot take sqrt of negative number %s", v)
+		}
+		return floatValue(math.Sqrt(float64(v))), nil
+	case tagFact:
+		if v.Negative() || !v.IsInteger() {
+			return v, fmt.Errorf("factorial requires a non-negative integer, got %s", v)
+		}
+		r := 1.0
+		for i := 2.0; i <= float64(v); i++ {
+			r *= i
+		}
+		return floatValue(r), nil
+	default:
+		return v, fmt.Errorf("unsupported unary op %s", op)
+	}
+}
+
+func (v floatValue) PerformBinary(op Op, v1 Value) (Value, error) {
+	w, ok := v1.(floatValue)
+	if !ok {
+		return v, fmt.Errorf("cannot combine float64 value with %T", v1)
+	}
+	switch op {
+	case tagAdd:
+		return v + w, nil
+	case tagSub:
+		return v - w, nil
+	case tagMul:
+		return v * w, nil
+	case tagDiv:
+		if w == 0 {
+			return v, fmt.Errorf("division by zero")
+		}
+		return v / w, nil
+	case tagPow:
+		return floatValue(math.Pow(float64(v), float64(w))), nil
+	default:
+		return v, fmt.Errorf("unsupported binary op %s", op)
+	}
+}
+
+func (v floatValue) Equal(v1 Value) bool {
+	w, ok := v1.(floatValue)
+	return ok && v == w
+}
+
+func (v floatValue) Less(v1 Value) bool {
+	w, ok := v1.(floatValue)
+	return ok && v < w
+}
+
+func (v floatValue) String() string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 64)
+}
+
+func (v floatValue) IsInteger() bool {
+	return v == floatValue(math.Trunc(float64(v)))
+}
+
+func (v floatValue) Negative() bool { return v < 0 }
+
+func (v floatValue) Even() bool {
+	return v.IsInteger() && math.Mod(float64(v), 2) == 0
+}
+
+func (v floatValue) Zero() bool     { return v == 0 }
+func (v floatValue) One() bool      { return v == 1 }
+func (v floatValue) MinusOne() bool { return v == -1 }
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokKind identifies the kind of a lexical token produced while parsing infix notation.
+type tokKind byte
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokOp
+	tokLParen
+	tokRParen
+	tokFunc
+)
+
+// token is a single lexical token: the text it was read from, and the byte
+// offset in the lexer's input where it starts, so parse failures can be
+// reported as a *ParseError pointing at the exact spot.
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+// lexer splits an infix expression into tokens. It understands integers,
+// rationals written as a/b, the operators +-*/^!, parentheses, and the
+// "sqrt" function name.
+type lexer struct {
+	s   string
+	pos int
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{s: s}
+}
+
+// errorf builds a *ParseError for a failure detected at offset bytes into l's
+// input.
+func (l *lexer) errorf(offset int, format string, args ...interface{}) error {
+	return newParseError(l.s, offset, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next token without consuming it.
+func (l *lexer) peek() (token, error) {
+	save := l.pos
+	tok, err := l.next()
+	l.pos = save
+	return tok, err
+}
+
+// next consumes and returns the next token.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.s) && unicode.IsSpace(rune(l.s[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.s) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.s[l.pos]
+	switch {
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.s) && l.s[l.pos] >= '0' && l.s[l.pos] <= '9' {
+			l.pos++
+		}
+		if l.pos < len(l.s) && l.s[l.pos] == '/' {
+			l.pos++
+			for l.pos < len(l.s) && l.s[l.pos] >= '0' && l.s[l.pos] <= '9' {
+				l.pos++
+			}
+		}
+		return token{kind: tokNum, text: l.s[start:l.pos], pos: start}, nil
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case strings.ContainsRune("+-*/^!", rune(c)):
+		l.pos++
+		return token{kind: tokOp, text: string(c), pos: start}, nil
+	case unicode.IsLetter(rune(c)):
+		for l.pos < len(l.s) && unicode.IsLetter(rune(l.s[l.pos])) {
+			l.pos++
+		}
+		name := l.s[start:l.pos]
+		if name != "sqrt" {
+			return token{}, l.errorf(start, "unrecognized identifier %q", name)
+		}
+		return token{kind: tokFunc, text: name, pos: start}, nil
+	default:
+		return token{}, l.errorf(start, "unexpected character %q", c)
+	}
+}
+
+// infixBinOps maps the single-character spelling of each binary operator to
+// its registry entry, which carries the precedence and associativity the
+// parser and pretty-printer need.
+var infixBinOps = map[string]*OpDef{
+	"+": OpAdd,
+	"-": OpSub,
+	"*": OpMul,
+	"/": OpDiv,
+	"^": OpPow,
+}
+
+// FromInfix parses s as a standard infix expression, e.g. "sqrt(2)*3 + (4-5)^2 - 6!/7",
+// with proper precedence, right-associative ^, postfix !, prefix unary minus, and
+// parenthesization.
+func FromInfix(s string) (*Node, error) {
+	l := newLexer(s)
+	n, err := infixExpr(l, 0)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind != tokEOF {
+		return nil, l.errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	return n, nil
+}
+
+// infixExpr implements the operator-precedence (Pratt) parsing loop: it consumes a
+// single prefix term, then keeps folding in binary operators as long as their
+// precedence is at least minPrec, recursing with prec+1 for left-associative
+// operators and prec for right-associative ones.
+func infixExpr(l *lexer, minPrec int) (*Node, error) {
+	left, err := infixPrefix(l)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, err := l.peek()
+		if err != nil {
+			return nil, err
+		}
+		if tok.kind == tokOp && tok.text == "!" {
+			l.next()
+			left = newNode(left, OpFact, nil)
+			continue
+		}
+		if tok.kind != tokOp {
+			break
+		}
+		op, ok := infixBinOps[tok.text]
+		if !ok || op.Prec < minPrec {
+			break
+		}
+		l.next()
+		nextMin := op.Prec + 1
+		if op.Assoc == AssocRight {
+			nextMin = op.Prec
+		}
+		right, err := infixExpr(l, nextMin)
+		if err != nil {
+			return nil, err
+		}
+		left = newNode(left, op, right)
+	}
+	return left, nil
+}
+
+// infixPrefix parses a single prefix term: a number, a parenthesized
+// sub-expression, sqrt(...), or a unary minus applied to the rest.
+func infixPrefix(l *lexer) (*Node, error) {
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokNum:
+		v, err := parseValue(tok.text)
+		if err != nil {
+			return nil, l.errorf(tok.pos, "%s", err)
+		}
+		return newValNode(v), nil
+	case tokOp:
+		if tok.text != "-" {
+			return nil, l.errorf(tok.pos, "unexpected operator %q", tok.text)
+		}
+		n, err := infixExpr(l, precUnaryMin)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(n, OpMinus, nil), nil
+	case tokLParen:
+		n, err := infixExpr(l, 0)
+		if err != nil {
+			return nil, err
+		}
+		close, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if close.kind != tokRParen {
+			return nil, l.errorf(close.pos, "expected ')', got %q", close.text)
+		}
+		return n, nil
+	case tokFunc:
+		open, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if open.kind != tokLParen {
+			return nil, l.errorf(open.pos, "expected '(' after %q", tok.text)
+		}
+		n, err := infixExpr(l, 0)
+		if err != nil {
+			return nil, err
+		}
+		close, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if close.kind != tokRParen {
+			return nil, l.errorf(close.pos, "expected ')', got %q", close.text)
+		}
+		return newNode(n, OpSqrt, nil), nil
+	default:
+		return nil, l.errorf(tok.pos, "expected operand, got %q", tok.text)
+	}
+}
+
+// ToInfix renders n in infix notation, adding only the parentheses required by
+// precedence and associativity.
+func (n *Node) ToInfix() string {
+	return n.toInfix(0)
+}
+
+// toInfix renders n, wrapping it in parentheses when its own precedence is lower
+// than parentPrec (the precedence context it is nested in).
+func (n *Node) toInfix(parentPrec int) string {
+	switch n.op {
+	case nil:
+		return n.val.String()
+	case OpMinus:
+		s := "-" + n.left.toInfix(precUnaryMin)
+		if precUnaryMin < parentPrec {
+			return "(" + s + ")"
+		}
+		return s
+	case OpFact:
+		return n.left.toInfix(precFact) + "!"
+	case OpSqrt:
+		return "sqrt(" + n.left.toInfix(0) + ")"
+	default:
+		// Left-assoc ops (+ - * /) don't need parens around a same-precedence
+		// left child; right-assoc ops (^) don't need them around a
+		// same-precedence right child.
+		leftPrec, rightPrec := n.op.Prec, n.op.Prec+1
+		if n.op.Assoc == AssocRight {
+			leftPrec, rightPrec = n.op.Prec+1, n.op.Prec
+		}
+		s := n.left.toInfix(leftPrec) + n.op.String() + n.right.toInfix(rightPrec)
+		if n.op.Prec < parentPrec {
+			return "(" + s + ")"
+		}
+		return s
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import "testing"
+
+func evalInfix(t *testing.T, s string) Value {
+	t.Helper()
+	n, err := FromInfix(s)
+	if err != nil {
+		t.Fatalf("FromInfix(%q): %v", s, err)
+	}
+	v, err := n.Eval()
+	if err != nil {
+		t.Fatalf("FromInfix(%q).Eval(): %v", s, err)
+	}
+	return v
+}
+
+func TestFromInfixPrecedenceAndAssociativity(t *testing.T) {
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"2^3^2", "512"},  // ^ is right-associative: 2^(3^2)
+		{"-2^2", "-4"},    // unary minus binds looser than ^: -(2^2)
+		{"-2*3", "-6"},    // unary minus binds tighter than *: (-2)*3
+		{"2+3*4", "14"},
+		{"(2+3)*4", "20"},
+		{"3!+1", "7"},
+		{"sqrt(9)+1", "4"},
+	}
+	for _, c := range cases {
+		got := evalInfix(t, c.expr)
+		want := mustValue(t, c.want)
+		if !got.Equal(want) {
+			t.Errorf("FromInfix(%q).Eval() = %s, want %s", c.expr, got, want)
+		}
+	}
+}
+
+func TestFromInfixUnaryMinusParsesAsOpMinus(t *testing.T) {
+	n, err := FromInfix("-(1+2)")
+	if err != nil {
+		t.Fatalf("FromInfix(%q): %v", "-(1+2)", err)
+	}
+	if n.op != OpMinus {
+		t.Fatalf("FromInfix(%q) root op = %v, want OpMinus (not OpSub)", "-(1+2)", n.op)
+	}
+}
+
+func TestInfixRoundTripsThroughToInfix(t *testing.T) {
+	for _, expr := range []string{
+		"2^3^2",
+		"-2^2",
+		"-2*3",
+		"(2+3)*4",
+		"2+3*4",
+		"sqrt(9)+1",
+		"3!+1",
+		"-(1+2)",
+	} {
+		n, err := FromInfix(expr)
+		if err != nil {
+			t.Fatalf("FromInfix(%q): %v", expr, err)
+		}
+		printed := n.ToInfix()
+		n2, err := FromInfix(printed)
+		if err != nil {
+			t.Fatalf("FromInfix(%q) (re-parsing ToInfix of %q): %v", printed, expr, err)
+		}
+		if !n.Equal(n2) {
+			t.Errorf("FromInfix(%q).ToInfix() = %q, which doesn't parse back to an equal tree", expr, printed)
+		}
+	}
+}
+
+func TestFromInfixReportsPositionOnError(t *testing.T) {
+	_, err := FromInfix("sqrt + 3")
+	if err == nil {
+		t.Fatal("FromInfix(\"sqrt + 3\") = nil error, want a *ParseError")
+	}
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("FromInfix(\"sqrt + 3\") error type = %T, want *ParseError", err)
+	}
+	if pe.Offset != 5 {
+		t.Errorf("ParseError.Offset = %d, want 5 (the '+' after \"sqrt \")", pe.Offset)
+	}
+}
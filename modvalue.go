@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ModulusP is the modulus used by the "mod" value backend. It should be kept
+// prime, since modInverse relies on Fermat's little theorem.
+var ModulusP int64 = 1000003
+
+// modValue is a Value backend doing arithmetic mod ModulusP, registered as
+// "mod". It is not meant to produce real answers: a digits solver can evaluate
+// a candidate formula mod P first, and only fall back to the exact rational
+// backend when the residue matches the target, cheaply pruning the vast
+// majority of formulas that can never equal it.
+type modValue int64
+
+func newModFromString(s string) (Value, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as a mod %d value: %s", s, ModulusP, err)
+	}
+	return modValue(norm(n)), nil
+}
+
+func init() {
+	RegisterValueBackend("mod", newModFromString)
+}
+
+func norm(n int64) int64 {
+	n %= ModulusP
+	if n < 0 {
+		n += ModulusP
+	}
+	return n
+}
+
+// modInverse returns the multiplicative inverse of n mod ModulusP.
+func modInverse(n int64) int64 {
+	r := int64(1)
+	b := norm(n)
+	for e := ModulusP - 2; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = norm(r * b)
+		}
+		b = norm(b * b)
+	}
+	return r
+}
+
+func (v modValue) PerformUnary(op Op) (Value, error) {
+	switch op {
+	case tagMinus:
+		return modValue(norm(-int64(v))), nil
+	case tagFact:
+		if v.Negative() {
+			return v, fmt.Errorf("factorial requires a non-negative value")
+		}
+		r := int64(1)
+		for i := int64(2); i <= int64(v); i++ {
+			r = norm(r * i)
+		}
+		return modValue(r), nil
+	case tagSqrt:
+		return v, fmt.Errorf("sqrt is not supported by the mod backend")
+	default:
+		return v, fmt.Errorf("unsupported unary op %s", op)
+	}
+}
+
+func (v modValue) PerformBinary(op Op, v1 Value) (Value, error) {
+	w, ok := v1.(modValue)
+	if !ok {
+		return v, fmt.Errorf("cannot combine mod value with %T", v1)
+	}
+	switch op {
+	case tagAdd:
+		return modValue(norm(int64(v) + int64(w))), nil
+	case tagSub:
+		return modValue(norm(int64(v) - int64(w))), nil
+	case tagMul:
+		return modValue(norm(int64(v) * int64(w))), nil
+	case tagDiv:
+		if w == 0 {
+			return v, fmt.Errorf("division by zero mod %d", ModulusP)
+		}
+		return modValue(norm(int64(v) * modInverse(int64(w)))), nil
+	case tagPow:
+		if w.Negative() {
+			return v, fmt.Errorf("mod backend only supports non-negative integer exponents")
+		}
+		r, b, e := int64(1), int64(v), int64(w)
+		for e > 0 {
+			if e&1 == 1 {
+				r = norm(r * b)
+			}
+			b = norm(b * b)
+			e >>= 1
+		}
+		return modValue(r), nil
+	default:
+		return v, fmt.Errorf("unsupported binary op %s", op)
+	}
+}
+
+func (v modValue) Equal(v1 Value) bool {
+	w, ok := v1.(modValue)
+	return ok && v == w
+}
+
+// Less orders residues numerically. It has no number-theoretic meaning mod P,
+// so the magnitude checks built on it (tooLarge, magnitudeTooLarge) compare
+// residues under this backend, not the integers they stand in for.
+func (v modValue) Less(v1 Value) bool {
+	w, ok := v1.(modValue)
+	return ok && v < w
+}
+
+func (v modValue) String() string {
+	return fmt.Sprintf("%d (mod %d)", int64(v), ModulusP)
+}
+
+func (v modValue) IsInteger() bool { return true }
+
+// Negative treats residues in the ring's upper half as standing in for negative
+// integers, mirroring how a real negative intermediate result would reduce mod P.
+func (v modValue) Negative() bool { return int64(v) > ModulusP/2 }
+func (v modValue) Even() bool     { return int64(v)%2 == 0 }
+func (v modValue) Zero() bool     { return v == 0 }
+func (v modValue) One() bool      { return v == 1 }
+func (v modValue) MinusOne() bool { return int64(v) == ModulusP-1 }
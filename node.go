@@ -3,51 +3,10 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
-// Op encode an operation that can be perfomed on the source digits.
-type Op byte
-
-const (
-	OpNull Op = iota
-	OpAdd     // Binary ops start here
-	OpSub
-	OpMul
-	OpDiv
-	OpPow
-	OpFact // Unary ops start here
-	OpSqrt
-	OpMinus // unary minus
-)
-
-var opNames = map[Op]string{
-	OpNull:  "null",
-	OpAdd:   "+",
-	OpSub:   "-",
-	OpMul:   "*",
-	OpDiv:   "/",
-	OpPow:   "^",
-	OpFact:  "!",
-	OpSqrt:  "sqrt",
-	OpMinus: "--",
-}
-
-// unary returns true for unary operators
-func (op Op) unary() bool {
-	return op >= OpFact
-}
-
-// binary returns true for binary operators
-func (op Op) binary() bool {
-	return op >= OpAdd && op <= OpPow
-}
-
-// String returns string representation for op
-func (op Op) String() string {
-	return opNames[op]
-}
-
 // Value defines an interface for anything on which above operations can be performed.
 type Value interface {
 	PerformUnary(Op) (Value, error)
@@ -66,18 +25,18 @@ type Value interface {
 
 // Node represents a formula parse tree, storing value (for a leaf) or
 // operand with left and right sub-nodes. Nodes with unary operators will have their
-// right sub-node nil, which is checked by Node.valid().
+// right sub-node nil, which is checked by Node.valid(). A nil op marks a leaf.
 type Node struct {
 	left, right *Node
 	val         Value
-	op          Op
+	op          *OpDef
 }
 
 // valid returns true for correct nodes. It does NOT check the subnodes recursively.
 func (n *Node) valid() bool {
-	if n.op == OpNull {
+	if n.op == nil {
 		return n.left == nil && n.right == nil
-	} else if n.op <= OpPow {
+	} else if n.op.binary() {
 		return n.left != nil && n.right != nil
 	} else {
 		return n.left != nil && n.right == nil
@@ -85,7 +44,7 @@ func (n *Node) valid() bool {
 }
 
 // newNode creates a new formula Node. It panics if requested Node will be not valid.
-func newNode(left *Node, op Op, right *Node) *Node {
+func newNode(left *Node, op *OpDef, right *Node) *Node {
 	n := &Node{left: left, op: op, right: right}
 	if !n.valid() {
 		panic(fmt.Sprintf("Cannot create non-valid node: %v %v %v", left, op, right))
@@ -98,41 +57,57 @@ func newValNode(val Value) *Node {
 	return &Node{val: val}
 }
 
-// newIntNode creates a new value Node from an integer.
+// newIntNode creates a new value Node from an integer, using the currently
+// selected Value backend (see RegisterValueBackend).
 func newIntNode(val int64) *Node {
-	r, _ := newRational(val, 1)
-	return &Node{val: r}
+	v, _ := parseValue(strconv.FormatInt(val, 10))
+	return &Node{val: v}
 }
 
-// FromPolish parses a node from a string, and returns an error if the input is invalid.
-// The input should be in Polish notation, with operands possibly separated by one or several spaces,
-// and rational numbers writen as a/b without any spaces around '/'. To avoid ambiguity,
-// unary minus should be encoded as --.
+// FromPolish parses a node from a string, and returns a *ParseError if the input
+// is invalid. The input should be in Polish notation, with operands possibly
+// separated by one or several spaces, and rational numbers writen as a/b
+// without any spaces around '/'. To avoid ambiguity, unary minus should be
+// encoded as --.
 // It reads as much as possible. See tests for some examples.
 func FromPolish(s string) (*Node, error) {
-	s = strings.TrimSpace(s)
-	nd, _, err := parseNodeFromString(s)
+	p := &polishParser{s: strings.TrimSpace(s)}
+	nd, err := p.parseNode()
 	if err != nil {
-		return nil, fmt.Errorf("cannot parse '%s': %s", s, err)
+		return nil, err
 	}
 	return nd, nil
 }
 
+// String renders n for use in error messages. Unlike ToPolish/ToInfix, it
+// tolerates malformed nodes (nil children, nil op, nil val), since it is also
+// used to describe why valid()/Eval rejected n in the first place.
+func (n *Node) String() string {
+	if n == nil {
+		return "<nil>"
+	}
+	if n.op == nil {
+		if n.val == nil {
+			return "<empty>"
+		}
+		return n.val.String()
+	}
+	s := n.op.String() + "(" + n.left.String()
+	if n.right != nil {
+		s += ", " + n.right.String()
+	}
+	return s + ")"
+}
+
 // ToPolish is an opposite of FromPolish: it returns a node writen in the polish notation.
 func (n *Node) ToPolish() string {
 	if !n.valid() {
 		return fmt.Sprintf("invalid formula: '%s'", n)
 	}
-	if n.op == OpNull {
+	if n.op == nil {
 		return n.val.String()
 	} else {
-		var s string
-		if n.op != OpMinus {
-			s = n.op.String()
-		} else {
-			s = "--"
-		}
-		s += " " + n.left.ToPolish()
+		s := n.op.String() + " " + n.left.ToPolish()
 		if n.op.binary() {
 			s += " " + n.right.ToPolish()
 		}
@@ -140,59 +115,88 @@ func (n *Node) ToPolish() string {
 	}
 }
 
-// parseNodeFromString does heavy lifting for parseString. It parses as much as possible and
-// returns the node parsed and the remainder of the string.
-func parseNodeFromString(s string) (*Node, string, error) {
-	s = strings.TrimSpace(s)
-	// Try to parse rational first
-	if ind := ratRx.FindStringIndex(s); ind != nil {
-		v, err := newRationalFromString(strings.TrimSpace(s[:ind[1]]))
-		if err != nil {
-			return nil, s[ind[1]:], err
-		}
-		return newValNode(v), s[ind[1]:], nil
+// lookupPolishOp looks up the operator or function at the start of s in the
+// registry, and returns it together with the number of bytes it occupies in s.
+// It returns nil if s doesn't start with a recognized operator.
+func lookupPolishOp(s string) (*OpDef, int) {
+	if strings.HasPrefix(s, "sqrt") {
+		return OpSqrt, 4
 	}
-	if s == "" {
-		return nil, "", fmt.Errorf("empty string")
+	if strings.HasPrefix(s, "--") {
+		return OpMinus, 2
 	}
-	var op Op
-	if strings.HasPrefix(s, "sqrt") {
-		op = OpSqrt
-		s = s[4:]
-	} else if strings.HasPrefix(s, "--") {
-		op = OpMinus
-		s = s[2:]
-	} else {
-		for k := range opNames {
-			if opNames[k] == s[:1] {
-				op = k
-				if op == OpMinus {
-					op = OpSub
-				}
-			}
+	for _, op := range polishOps {
+		if op.Name == s[:1] {
+			return op, 1
 		}
-		if op == OpNull {
-			return nil, s[1:], fmt.Errorf("unrecognized operator in '%s'", s)
+	}
+	return nil, 0
+}
+
+// polishParser parses Polish notation out of s, tracking the byte offset of
+// the current read position within s so that failures can be reported as a
+// *ParseError pointing at the exact spot, the same way go/token.FileSet
+// locates errors for the Go parser.
+type polishParser struct {
+	s   string
+	pos int
+}
+
+// skipSpace advances pos past any run of whitespace.
+func (p *polishParser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\n\r\v\f", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// rest returns the not-yet-consumed part of p.s.
+func (p *polishParser) rest() string {
+	return p.s[p.pos:]
+}
+
+func (p *polishParser) errorf(format string, args ...interface{}) error {
+	return newParseError(p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseNode parses as much of the remaining input as one formula needs.
+func (p *polishParser) parseNode() (*Node, error) {
+	p.skipSpace()
+	rest := p.rest()
+	// Try to parse a number literal first, using the currently selected Value
+	// backend (see RegisterValueBackend).
+	if ind := ratRx.FindStringIndex(rest); ind != nil {
+		lit := strings.TrimSpace(rest[:ind[1]])
+		start := p.pos
+		p.pos += ind[1]
+		v, err := parseValue(lit)
+		if err != nil {
+			return nil, newParseError(p.s, start, err.Error())
 		}
-		s = s[1:]
+		return newValNode(v), nil
+	}
+	if rest == "" {
+		return nil, p.errorf("empty string")
+	}
+	op, n := lookupPolishOp(rest)
+	if op == nil {
+		return nil, p.errorf("unrecognized operator in '%s'", rest)
 	}
-	if s == "" {
-		return nil, s, fmt.Errorf("first operand missing")
+	p.pos += n
+	if p.rest() == "" {
+		return nil, p.errorf("first operand missing")
 	}
-	n1, s1, err := parseNodeFromString(s)
+	n1, err := p.parseNode()
 	if err != nil {
-		return nil, s1, err
+		return nil, err
 	}
 	if op.unary() {
-		return newNode(n1, op, nil), s1, nil
-	} else {
-		n2, s2, err := parseNodeFromString(s1)
-		if err != nil {
-			return nil, s2, fmt.Errorf("second operand missing")
-		} else {
-			return newNode(n1, op, n2), s2, nil
-		}
+		return newNode(n1, op, nil), nil
+	}
+	n2, err := p.parseNode()
+	if err != nil {
+		return nil, err
 	}
+	return newNode(n1, op, n2), nil
 }
 
 var ratRx *regexp.Regexp // Regular expression for a rational number
@@ -203,7 +207,7 @@ func init() {
 
 // Depth returns distance of the deepest leaf to the root.
 func (n *Node) Depth() int64 {
-	if n.op == OpNull {
+	if n.op == nil {
 		return 0
 	}
 	depth := n.left.Depth()
@@ -220,7 +224,7 @@ func (n *Node) Equal(n1 *Node) bool {
 	if n1 == nil || n.op != n1.op {
 		return false
 	}
-	if n.op != OpNull {
+	if n.op != nil {
 		return n.left.Equal(n1.left) && (n.right == nil || n.right.Equal(n1.right))
 	} else {
 		return n.val.Equal(n1.val)
@@ -231,50 +235,50 @@ func (n *Node) Equal(n1 *Node) bool {
 // or cannot be represented by a rational.
 func (n *Node) Eval() (Value, error) {
 	if !n.valid() {
-		return rational{}, fmt.Errorf("invalid formula %s", n)
+		return nil, fmt.Errorf("invalid formula %s", n)
 	}
-	if n.op == OpNull {
+	if n.op == nil {
 		return n.val, nil
 	} else if n.op.binary() {
 		left, err := n.left.Eval()
 		if err != nil {
-			return n.val, err
+			return nil, err
 		}
 		right, err := n.right.Eval()
 		if err != nil {
-			return n.val, err
+			return nil, err
 		}
-		return left.PerformBinary(n.op, right)
+		return n.op.applyBinary(left, right)
 	} else {
 		left, err := n.left.Eval()
 		if err != nil {
-			return n.val, err
+			return nil, err
 		}
-		return left.PerformUnary(n.op)
+		return n.op.applyUnary(left)
 	}
 }
 
 // transformDuo transorms all expressions of the form (op1 a) op2 (op3 b) into op4 (a op5 b),
-// and leaves other expressions intact. In the form above, (OpNull x) is treated as x.
-func (n *Node) transformDuo(op1, op2, op3, op4, op5 Op) *Node {
+// and leaves other expressions intact. In the form above, (nil x) is treated as x.
+func (n *Node) transformDuo(op1, op2, op3, op4, op5 *OpDef) *Node {
 	var a, b *Node
 	if n.op == op2 {
 		if n.left.op == op1 && n.left.left != nil {
 			a = n.left.left.Simplify()
-		} else if op1 == OpNull {
+		} else if op1 == nil {
 			a = n.left.Simplify()
 		} else {
 			return n
 		}
 		if n.right.op == op3 && n.right.left != nil {
 			b = n.right.left.Simplify()
-		} else if op3 == OpNull {
+		} else if op3 == nil {
 			b = n.right.Simplify()
 		} else {
 			return n
 		}
 		n1 := &Node{op: op5, left: a, right: b}
-		if op4 != OpNull {
+		if op4 != nil {
 			n1 = &Node{op: op4, left: n1.Simplify()}
 		}
 		return n1
@@ -285,7 +289,7 @@ func (n *Node) transformDuo(op1, op2, op3, op4, op5 Op) *Node {
 
 // transformTrio transforms an expression of the form a op1 (b op2 c) into (a op3 b) op4 c,
 // and leaves other expressions intact.
-func (n *Node) transformTrio(op1, op2, op3, op4 Op) *Node {
+func (n *Node) transformTrio(op1, op2, op3, op4 *OpDef) *Node {
 	if n.op == op1 && n.right.op == op2 {
 		n1 := &Node{op: op3, left: n.left.Simplify(), right: n.right.left.Simplify()}
 		return &Node{op: op4, left: n1.Simplify(), right: n.right.right.Simplify()}
@@ -308,7 +312,7 @@ func (n *Node) Simplify() *Node {
 		}
 	} else {
 		n1 = n
-		for _, t := range [][5]Op{
+		for _, t := range [][5]*OpDef{
 			{OpNull, OpAdd, OpMinus, OpNull, OpSub},
 			{OpNull, OpAdd, OpMinus, OpNull, OpSub},
 			{OpNull, OpSub, OpMinus, OpNull, OpAdd},
@@ -325,7 +329,7 @@ func (n *Node) Simplify() *Node {
 		} {
 			n1 = n1.transformDuo(t[0], t[1], t[2], t[3], t[4])
 		}
-		for _, t := range [][4]Op{
+		for _, t := range [][4]*OpDef{
 			{OpAdd, OpAdd, OpAdd, OpAdd},
 			{OpSub, OpSub, OpSub, OpAdd},
 			{OpMul, OpMul, OpMul, OpMul},
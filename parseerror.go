@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError reports a parse failure together with where in the input it
+// occurred, mirroring how go/token.FileSet/token.Pos locate errors in the Go
+// toolchain.
+type ParseError struct {
+	Input   string // the full input that was being parsed
+	Offset  int    // byte offset into Input where the error was detected
+	Line    int    // 1-based line number at Offset
+	Col     int    // 1-based column number at Offset
+	Snippet string // the line of Input containing Offset
+	Msg     string // what went wrong
+}
+
+// newParseError builds a ParseError for a failure detected at offset bytes
+// into input.
+func newParseError(input string, offset int, msg string) *ParseError {
+	line, col := 1, 1
+	for _, r := range input[:offset] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	lineStart := strings.LastIndexByte(input[:offset], '\n') + 1
+	lineEnd := strings.IndexByte(input[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(input)
+	} else {
+		lineEnd += offset
+	}
+	return &ParseError{
+		Input:   input,
+		Offset:  offset,
+		Line:    line,
+		Col:     col,
+		Snippet: input[lineStart:lineEnd],
+		Msg:     msg,
+	}
+}
+
+// Error renders a caret-underlined excerpt of the offending line, e.g.
+//
+//	sqrt + 3
+//	     ^ expected operand
+func (e *ParseError) Error() string {
+	col := e.Col - 1
+	if col < 0 {
+		col = 0
+	}
+	return fmt.Sprintf("%s\n%s^ %s", e.Snippet, strings.Repeat(" ", col), e.Msg)
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestNewParseErrorLineAndCol(t *testing.T) {
+	input := "1 + +\nsqrt(2"
+	// offset 4 is the second '+', still on line 1.
+	e := newParseError(input, 4, "unexpected operator")
+	if e.Line != 1 || e.Col != 5 {
+		t.Errorf("newParseError(%q, 4, ...): Line=%d Col=%d, want Line=1 Col=5", input, e.Line, e.Col)
+	}
+	if e.Snippet != "1 + +" {
+		t.Errorf("Snippet = %q, want %q", e.Snippet, "1 + +")
+	}
+
+	// offset 10 is the '(' on the second line.
+	e2 := newParseError(input, 10, "expected ')'")
+	if e2.Line != 2 || e2.Col != 5 {
+		t.Errorf("newParseError(%q, 10, ...): Line=%d Col=%d, want Line=2 Col=5", input, e2.Line, e2.Col)
+	}
+	if e2.Snippet != "sqrt(2" {
+		t.Errorf("Snippet = %q, want %q", e2.Snippet, "sqrt(2")
+	}
+}
+
+func TestParseErrorRendersCaretUnderline(t *testing.T) {
+	e := newParseError("sqrt + 3", 5, "expected operand")
+	want := "sqrt + 3\n     ^ expected operand"
+	if got := e.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestParseErrorAtStartOfLine(t *testing.T) {
+	e := newParseError("+3", 0, "unexpected operator")
+	want := "+3\n^ unexpected operator"
+	if got := e.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
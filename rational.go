@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rational is the default Value backend: an exact fraction num/den, always
+// kept in lowest terms with a positive denominator.
+type rational struct {
+	num, den int64
+}
+
+// gcdInt64 returns the greatest common divisor of a and b, treating 0 as
+// matching anything so newRational never divides by zero when num is 0.
+func gcdInt64(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return 1
+	}
+	return a
+}
+
+// newRational returns num/den reduced to lowest terms, with a positive
+// denominator. It errors if den is zero.
+func newRational(num, den int64) (rational, error) {
+	if den == 0 {
+		return rational{}, fmt.Errorf("division by zero")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	g := gcdInt64(num, den)
+	return rational{num: num / g, den: den / g}, nil
+}
+
+// newRationalFromString parses s, written as "a" or "a/b", into a rational Value.
+func newRationalFromString(s string) (Value, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.SplitN(s, "/", 2)
+	num, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as a rational: %s", s, err)
+	}
+	den := int64(1)
+	if len(parts) == 2 {
+		den, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse '%s' as a rational: %s", s, err)
+		}
+	}
+	r, err := newRational(num, den)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse '%s': %s", s, err)
+	}
+	return r, nil
+}
+
+// isqrt returns the floor of the integer square root of n, which must be
+// non-negative.
+func isqrt(n int64) int64 {
+	x := int64(math.Sqrt(float64(n)))
+	for x*x > n {
+		x--
+	}
+	for (x+1)*(x+1) <= n {
+		x++
+	}
+	return x
+}
+
+func (r rational) PerformUnary(op Op) (Value, error) {
+	switch op {
+	case tagMinus:
+		return rational{-r.num, r.den}, nil
+	case tagSqrt:
+		if r.Negative() {
+			return r, fmt.Errorf("cannot take sqrt of negative number %s", r)
+		}
+		ns, ds := isqrt(r.num), isqrt(r.den)
+		if ns*ns != r.num || ds*ds != r.den {
+			return r, fmt.Errorf("sqrt of %s is not rational", r)
+		}
+		return newRational(ns, ds)
+	case tagFact:
+		if r.Negative() || !r.IsInteger() {
+			return r, fmt.Errorf("factorial requires a non-negative integer, got %s", r)
+		}
+		f := int64(1)
+		for i := int64(2); i <= r.num; i++ {
+			f *= i
+		}
+		return newRational(f, 1)
+	default:
+		return r, fmt.Errorf("unsupported unary op %s", op)
+	}
+}
+
+func (r rational) PerformBinary(op Op, v Value) (Value, error) {
+	w, ok := v.(rational)
+	if !ok {
+		return r, fmt.Errorf("cannot combine rational value with %T", v)
+	}
+	switch op {
+	case tagAdd:
+		return newRational(r.num*w.den+w.num*r.den, r.den*w.den)
+	case tagSub:
+		return newRational(r.num*w.den-w.num*r.den, r.den*w.den)
+	case tagMul:
+		return newRational(r.num*w.num, r.den*w.den)
+	case tagDiv:
+		if w.num == 0 {
+			return r, fmt.Errorf("division by zero")
+		}
+		return newRational(r.num*w.den, r.den*w.num)
+	case tagPow:
+		if !w.IsInteger() {
+			return r, fmt.Errorf("rational backend only supports integer exponents, got %s", w)
+		}
+		n := w.num
+		neg := n < 0
+		if neg {
+			n = -n
+		}
+		num, den := int64(1), int64(1)
+		for i := int64(0); i < n; i++ {
+			num *= r.num
+			den *= r.den
+		}
+		if neg {
+			num, den = den, num
+		}
+		return newRational(num, den)
+	default:
+		return r, fmt.Errorf("unsupported binary op %s", op)
+	}
+}
+
+func (r rational) Equal(v Value) bool {
+	w, ok := v.(rational)
+	return ok && r.num == w.num && r.den == w.den
+}
+
+func (r rational) Less(v Value) bool {
+	w, ok := v.(rational)
+	return ok && r.num*w.den < w.num*r.den
+}
+
+func (r rational) String() string {
+	if r.den == 1 {
+		return strconv.FormatInt(r.num, 10)
+	}
+	return fmt.Sprintf("%d/%d", r.num, r.den)
+}
+
+func (r rational) IsInteger() bool { return r.den == 1 }
+func (r rational) Negative() bool  { return r.num < 0 }
+func (r rational) Even() bool      { return r.IsInteger() && r.num%2 == 0 }
+func (r rational) Zero() bool      { return r.num == 0 }
+func (r rational) One() bool       { return r.num == 1 && r.den == 1 }
+func (r rational) MinusOne() bool  { return r.num == -1 && r.den == 1 }
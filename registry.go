@@ -0,0 +1,178 @@
+package main
+
+import "fmt"
+
+// Assoc describes how a binary operator associates when chained with itself,
+// e.g. a-b-c == (a-b)-c for AssocLeft, a^b^c == a^(b^c) for AssocRight.
+type Assoc int
+
+const (
+	AssocLeft Assoc = iota
+	AssocRight
+)
+
+// Op is an internal dispatch tag: it is what Value implementations switch on
+// inside PerformUnary/PerformBinary to tell built-in operators apart. It is not
+// part of the public operator API, which is OpDef and the registry below.
+type Op byte
+
+const (
+	tagAdd Op = iota
+	tagSub
+	tagMul
+	tagDiv
+	tagPow
+	tagFact
+	tagSqrt
+	tagMinus
+)
+
+// String returns a short name for op, for use in error messages.
+func (op Op) String() string {
+	switch op {
+	case tagAdd:
+		return "+"
+	case tagSub:
+		return "-"
+	case tagMul:
+		return "*"
+	case tagDiv:
+		return "/"
+	case tagPow:
+		return "^"
+	case tagFact:
+		return "!"
+	case tagSqrt:
+		return "sqrt"
+	case tagMinus:
+		return "--"
+	default:
+		return "?"
+	}
+}
+
+// OpDef describes a registered operator or function: its display name, the
+// precedence and associativity used by the infix parser and pretty-printer,
+// and how to evaluate it. Node carries an *OpDef instead of a fixed enum, so
+// RegisterUnary/RegisterBinary can add operators (log, abs, gcd, %, //, ...)
+// without editing the core. A nil *OpDef marks a leaf node.
+type OpDef struct {
+	Name  string
+	Prec  int
+	Assoc Assoc
+
+	tag      Op // dispatch tag for the built-in operators; unused by custom ones
+	unaryFn  func(Value) (Value, error)
+	binaryFn func(Value, Value) (Value, error)
+}
+
+// unary reports whether op takes a single operand.
+func (op *OpDef) unary() bool {
+	return op != nil && (op.unaryFn != nil || op.tag == tagFact || op.tag == tagSqrt || op.tag == tagMinus)
+}
+
+// binary reports whether op takes two operands.
+func (op *OpDef) binary() bool {
+	return op != nil && !op.unary()
+}
+
+// String returns op's display name, or "" for a leaf (nil op).
+func (op *OpDef) String() string {
+	if op == nil {
+		return ""
+	}
+	return op.Name
+}
+
+// applyUnary evaluates op applied to v.
+func (op *OpDef) applyUnary(v Value) (Value, error) {
+	if op.unaryFn != nil {
+		return op.unaryFn(v)
+	}
+	return v.PerformUnary(op.tag)
+}
+
+// applyBinary evaluates op applied to l and r.
+func (op *OpDef) applyBinary(l, r Value) (Value, error) {
+	if op.binaryFn != nil {
+		return op.binaryFn(l, r)
+	}
+	return l.PerformBinary(op.tag, r)
+}
+
+var opRegistry = map[string]*OpDef{}
+
+// RegisterUnary registers a unary operator or function under name, with the
+// given precedence and implementation, and returns it for callers that want to
+// keep a reference. A registered operator can be found with LookupOp and
+// evaluated in a Node built with newNode; FromPolish and FromInfix recognize
+// only the built-in operators, and ToInfix renders only the built-in unary ones.
+func RegisterUnary(name string, prec int, fn func(Value) (Value, error)) *OpDef {
+	op := &OpDef{Name: name, Prec: prec, unaryFn: fn}
+	opRegistry[name] = op
+	return op
+}
+
+// RegisterBinary registers a binary operator under name, with the given
+// precedence, associativity and implementation, and returns it for callers
+// that want to keep a reference. ToInfix uses its precedence and
+// associativity; FromPolish and FromInfix recognize only the built-in
+// operators, not registered ones.
+func RegisterBinary(name string, prec int, assoc Assoc, fn func(Value, Value) (Value, error)) *OpDef {
+	op := &OpDef{Name: name, Prec: prec, Assoc: assoc, binaryFn: fn}
+	opRegistry[name] = op
+	return op
+}
+
+// registerBuiltin pre-registers one of the historical fixed operators, for
+// backward compatibility: it still dispatches through
+// Value.PerformUnary/PerformBinary via tag rather than through an explicit fn,
+// since every Value backend already implements the built-in set that way.
+func registerBuiltin(name string, prec int, assoc Assoc, tag Op) *OpDef {
+	op := &OpDef{Name: name, Prec: prec, Assoc: assoc, tag: tag}
+	opRegistry[name] = op
+	return op
+}
+
+// LookupOp returns the operator or function registered under name, or nil if
+// none is registered under that name.
+func LookupOp(name string) *OpDef {
+	return opRegistry[name]
+}
+
+// Precedence levels shared by the infix parser/pretty-printer and the
+// built-in operator registrations below. Higher binds tighter. Unary minus
+// sits between * / and ^ so that -2^2 == -(2^2) while -2*3 == (-2)*3.
+const (
+	precAddSub   = 1
+	precMulDiv   = 2
+	precUnaryMin = 3
+	precPow      = 4
+	precFact     = 5
+)
+
+// OpNull marks a leaf node; it is always nil.
+var OpNull *OpDef
+
+// The built-in operators, pre-registered so existing code (and Simplify's
+// rewrite tables) can keep referring to them by name.
+var (
+	OpAdd   = registerBuiltin("+", precAddSub, AssocLeft, tagAdd)
+	OpSub   = registerBuiltin("-", precAddSub, AssocLeft, tagSub)
+	OpMul   = registerBuiltin("*", precMulDiv, AssocLeft, tagMul)
+	OpDiv   = registerBuiltin("/", precMulDiv, AssocLeft, tagDiv)
+	OpPow   = registerBuiltin("^", precPow, AssocRight, tagPow)
+	OpFact  = registerBuiltin("!", precFact, AssocLeft, tagFact)
+	OpSqrt  = registerBuiltin("sqrt", precFact, AssocLeft, tagSqrt)
+	OpMinus = registerBuiltin("--", precUnaryMin, AssocLeft, tagMinus)
+)
+
+// polishOps lists the built-ins consulted by lookupPolishOp when looking
+// for a single-character Polish-notation operator.
+var polishOps = []*OpDef{OpAdd, OpSub, OpMul, OpDiv, OpPow, OpFact}
+
+func init() {
+	if OpNull != nil {
+		panic(fmt.Sprintf("OpNull must stay nil, got %v", OpNull))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestOpNullIsAlwaysNil(t *testing.T) {
+	if OpNull != nil {
+		t.Fatalf("OpNull = %v, want nil", OpNull)
+	}
+	if OpNull.binary() || OpNull.unary() {
+		t.Error("OpNull must be neither unary nor binary")
+	}
+}
+
+func TestLookupOpReturnsRegisteredBuiltins(t *testing.T) {
+	cases := map[string]*OpDef{
+		"+":    OpAdd,
+		"-":    OpSub,
+		"*":    OpMul,
+		"/":    OpDiv,
+		"^":    OpPow,
+		"!":    OpFact,
+		"sqrt": OpSqrt,
+		"--":   OpMinus,
+	}
+	for name, want := range cases {
+		if got := LookupOp(name); got != want {
+			t.Errorf("LookupOp(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if got := LookupOp("nonexistent"); got != nil {
+		t.Errorf("LookupOp(%q) = %v, want nil", "nonexistent", got)
+	}
+}
+
+func TestOpDefUnaryBinaryClassification(t *testing.T) {
+	for _, op := range []*OpDef{OpAdd, OpSub, OpMul, OpDiv} {
+		if !op.binary() || op.unary() {
+			t.Errorf("%s: binary()=%v unary()=%v, want binary only", op, op.binary(), op.unary())
+		}
+	}
+	for _, op := range []*OpDef{OpFact, OpSqrt, OpMinus} {
+		if !op.unary() || op.binary() {
+			t.Errorf("%s: unary()=%v binary()=%v, want unary only", op, op.unary(), op.binary())
+		}
+	}
+}
+
+func TestRegisterBinaryAddsCustomOperator(t *testing.T) {
+	// A minimal custom operator, registered the way a caller outside this
+	// package would (via the tag-free binaryFn path rather than a builtin tag).
+	avg := RegisterBinary("avg", precAddSub, AssocLeft, func(l, r Value) (Value, error) {
+		sum, err := l.PerformBinary(tagAdd, r)
+		if err != nil {
+			return nil, err
+		}
+		return sum.PerformBinary(tagDiv, mustValue(t, "2"))
+	})
+	if got := LookupOp("avg"); got != avg {
+		t.Fatalf("LookupOp(%q) = %v, want the just-registered op", "avg", got)
+	}
+	if !avg.binary() || avg.unary() {
+		t.Fatalf("custom binary op: binary()=%v unary()=%v, want binary only", avg.binary(), avg.unary())
+	}
+	got, err := avg.applyBinary(mustValue(t, "7"), mustValue(t, "3"))
+	if err != nil {
+		t.Fatalf("applyBinary(7, 3): %v", err)
+	}
+	if want := mustValue(t, "5"); !got.Equal(want) {
+		t.Errorf("avg(7, 3) = %s, want 5", got)
+	}
+}
+
+func TestOpDefStringIsNameOrEmptyForLeaf(t *testing.T) {
+	if OpNull.String() != "" {
+		t.Errorf("OpNull.String() = %q, want \"\"", OpNull.String())
+	}
+	if OpAdd.String() != "+" {
+		t.Errorf("OpAdd.String() = %q, want \"+\"", OpAdd.String())
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import "strconv"
+
+// Sanity bounds applied while enumerating formulas, to keep factorial and power
+// from blowing up the search (and the resulting numbers) well past anything a
+// digits puzzle target could need.
+const (
+	maxFactOperand = 12
+	maxPowExponent = 10
+	maxPowBase     = 1 << 20
+)
+
+// Solve searches the values reachable from the multiset of source digits using
+// the operators in ops (within the sanity bounds above), and returns a formula
+// equal to target if one is found, simplified with Node.Simplify. Since
+// solveIndices keeps only the first formula found for each distinct value, at
+// most one formula is returned; other formulas with the same value are not
+// enumerated. Candidates are still deduplicated by a canonical hash.
+func Solve(digits []int64, target Value, ops []*OpDef) []*Node {
+	leaves := make([]*Node, len(digits))
+	for i, d := range digits {
+		leaves[i] = newIntNode(d)
+	}
+	unary, binary := splitOps(ops)
+	full := (1 << uint(len(digits))) - 1
+	memo := make(map[int][]*Node)
+	candidates := solveIndices(full, leaves, binary, unary, memo)
+
+	seen := make(map[string]bool)
+	var out []*Node
+	for _, n := range candidates {
+		v, err := n.Eval()
+		if err != nil || !v.Equal(target) {
+			continue
+		}
+		s := n.Simplify()
+		h := canonicalHash(s)
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// splitOps partitions ops into its unary and binary members.
+func splitOps(ops []*OpDef) (unary, binary []*OpDef) {
+	for _, op := range ops {
+		if op.unary() {
+			unary = append(unary, op)
+		} else if op.binary() {
+			binary = append(binary, op)
+		}
+	}
+	return unary, binary
+}
+
+// solveIndices returns every formula reachable from exactly the digits selected
+// by mask (a bitmask over leaves), by trying every way to split mask into two
+// non-empty parts, recursively solving each part, combining with every binary
+// op, and finally applying every unary op to the results. Results are memoized
+// per mask since the same sub-mask recurs across many splits of its ancestors.
+//
+// Candidates are deduplicated by value as soon as they're produced, keeping
+// only the first formula found for each distinct value: without this, the
+// candidate list for a mask grows combinatorially (many distinct formulas
+// evaluate to the same handful of values), and splits of larger masks combine
+// every one of those redundant duplicates all over again.
+func solveIndices(mask int, leaves []*Node, binary, unary []*OpDef, memo map[int][]*Node) []*Node {
+	if cached, ok := memo[mask]; ok {
+		return cached
+	}
+	var results []*Node
+	seen := make(map[string]bool)
+	if mask&(mask-1) == 0 { // a single digit
+		leaf := leaves[trailingBit(mask)]
+		results = []*Node{leaf}
+		if v, err := leaf.Eval(); err == nil {
+			seen[v.String()] = true
+		}
+	} else {
+		for sub := (mask - 1) & mask; sub > 0; sub = (sub - 1) & mask {
+			other := mask &^ sub
+			left := solveIndices(sub, leaves, binary, unary, memo)
+			right := solveIndices(other, leaves, binary, unary, memo)
+			for _, l := range left {
+				lv, err := l.Eval()
+				if err != nil {
+					continue
+				}
+				for _, r := range right {
+					rv, err := r.Eval()
+					if err != nil {
+						continue
+					}
+					for _, op := range binary {
+						if degenerateBinary(op, lv, rv) || !binaryBounds(op, lv, rv) {
+							continue
+						}
+						n := newNode(l, op, r)
+						v, err := n.Eval()
+						if err != nil {
+							continue
+						}
+						key := v.String()
+						if seen[key] {
+							continue
+						}
+						seen[key] = true
+						results = append(results, n)
+					}
+				}
+			}
+		}
+	}
+	results = applyUnary(results, unary, seen)
+	memo[mask] = results
+	return results
+}
+
+// applyUnary returns nodes together with a copy wrapped in each unary op in
+// unary, for every op that passes its sanity bounds, skipping any wrapped
+// formula whose value is already present (as a key in seen, which the caller
+// seeds with the values of nodes itself).
+func applyUnary(nodes []*Node, unary []*OpDef, seen map[string]bool) []*Node {
+	out := append([]*Node{}, nodes...)
+	for _, n := range nodes {
+		v, err := n.Eval()
+		if err != nil {
+			continue
+		}
+		for _, op := range unary {
+			if !unaryBounds(op, v) {
+				continue
+			}
+			un := newNode(n, op, nil)
+			uv, err := un.Eval()
+			if err != nil {
+				continue
+			}
+			key := uv.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, un)
+		}
+	}
+	return out
+}
+
+// degenerateBinary reports whether combining lv and rv with op is trivially
+// degenerate (x-x, x/x, x*1, x+0, ...) and therefore not worth generating.
+func degenerateBinary(op *OpDef, lv, rv Value) bool {
+	switch op {
+	case OpSub:
+		return lv.Equal(rv)
+	case OpDiv:
+		return lv.Equal(rv) || rv.One()
+	case OpMul:
+		return lv.One() || rv.One() || lv.Zero() || rv.Zero()
+	case OpAdd:
+		return lv.Zero() || rv.Zero()
+	default:
+		return false
+	}
+}
+
+// binaryBounds rejects combinations whose operands or result could blow up:
+// any operand already outside maxPowBase in magnitude (positive or negative)
+// is rejected outright, since left unchecked it could feed an enormous value
+// into a later operation, and a power additionally needs a small exponent.
+func binaryBounds(op *OpDef, lv, rv Value) bool {
+	if magnitudeTooLarge(lv, maxPowBase) || magnitudeTooLarge(rv, maxPowBase) {
+		return false
+	}
+	if op != OpPow {
+		return true
+	}
+	return !magnitudeTooLarge(rv, maxPowExponent)
+}
+
+// unaryBounds rejects unary applications that could blow up, namely a factorial
+// of too large an operand, or sqrt of a negative number.
+func unaryBounds(op *OpDef, v Value) bool {
+	switch op {
+	case OpFact:
+		return !v.Negative() && v.IsInteger() && !magnitudeTooLarge(v, maxFactOperand)
+	case OpSqrt:
+		return !v.Negative()
+	default:
+		return true
+	}
+}
+
+// tooLarge reports whether v exceeds bound, in the currently selected Value
+// backend. It only checks the positive direction; callers that need to reject
+// large-magnitude values of either sign should use magnitudeTooLarge instead.
+func tooLarge(v Value, bound int64) bool {
+	b, err := parseValue(strconv.FormatInt(bound, 10))
+	if err != nil {
+		return false
+	}
+	return b.Less(v)
+}
+
+// trailingBit returns the index of the single set bit in mask.
+func trailingBit(mask int) int {
+	i := 0
+	for mask&1 == 0 {
+		mask >>= 1
+		i++
+	}
+	return i
+}
+
+// canonicalHash renders n as a string that is identical for formulas differing
+// only in the operand order of a commutative operator, so they can be
+// deduplicated after Simplify.
+func canonicalHash(n *Node) string {
+	if n.op == nil {
+		return "v:" + n.val.String()
+	}
+	left := canonicalHash(n.left)
+	if n.right == nil {
+		return n.op.String() + "(" + left + ")"
+	}
+	right := canonicalHash(n.right)
+	if commutative(n.op) && right < left {
+		left, right = right, left
+	}
+	return n.op.String() + "(" + left + "," + right + ")"
+}
+
+// commutative reports whether op's operands can be swapped without changing
+// the result, for canonicalHash's purposes.
+func commutative(op *OpDef) bool {
+	return op == OpAdd || op == OpMul
+}
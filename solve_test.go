@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func mustValue(t *testing.T, s string) Value {
+	t.Helper()
+	v, err := parseValue(s)
+	if err != nil {
+		t.Fatalf("parseValue(%q): %v", s, err)
+	}
+	return v
+}
+
+func TestSolveDedupesCommutativeOperandOrder(t *testing.T) {
+	target := mustValue(t, "6")
+	got := Solve([]int64{2, 3}, target, []*OpDef{OpAdd, OpSub, OpMul, OpDiv})
+	if len(got) != 1 {
+		forms := make([]string, len(got))
+		for i, n := range got {
+			forms[i] = n.ToPolish()
+		}
+		t.Fatalf("Solve([2,3], 6) = %d formulas %v, want exactly 1 (2*3 and 3*2 should dedupe)", len(got), forms)
+	}
+}
+
+func TestSolveDedupesAcrossSubtreeShapes(t *testing.T) {
+	target := mustValue(t, "6")
+	got := Solve([]int64{1, 2, 3}, target, []*OpDef{OpAdd, OpSub, OpMul, OpDiv})
+	seen := make(map[string]bool)
+	for _, n := range got {
+		h := canonicalHash(n.Simplify())
+		if seen[h] {
+			t.Fatalf("Solve([1,2,3], 6) returned two formulas with the same canonical hash %q: %s", h, n.ToPolish())
+		}
+		seen[h] = true
+	}
+	if len(got) == 0 {
+		t.Fatal("Solve([1,2,3], 6) returned no formulas, want at least one (e.g. 1+2+3)")
+	}
+}
+
+// Regression test for a reviewed bug: tooLarge only checked the positive
+// direction, so a large-magnitude negative operand sailed through
+// binaryBounds/unaryBounds unchecked.
+func TestTooLargeAndMagnitudeTooLargeHandleBothSigns(t *testing.T) {
+	big := mustValue(t, "-999999999")
+	if tooLarge(big, 10) {
+		t.Error("tooLarge(-999999999, 10) = true, want false: tooLarge only checks the positive direction by design")
+	}
+	if !magnitudeTooLarge(big, 10) {
+		t.Error("magnitudeTooLarge(-999999999, 10) = false, want true: a large negative magnitude must be rejected")
+	}
+}
+
+// Regression test for a reviewed performance bug: without per-mask dedup by
+// value, the candidate list at each sub-mask grows combinatorially and a
+// standard 6-digit Countdown-sized puzzle never finishes.
+func TestSolveHandlesSixDigitsWithinTimeout(t *testing.T) {
+	target := mustValue(t, "952")
+	ops := []*OpDef{OpAdd, OpSub, OpMul, OpDiv}
+	done := make(chan []*Node, 1)
+	go func() {
+		done <- Solve([]int64{25, 50, 75, 100, 3, 6}, target, ops)
+	}()
+	select {
+	case got := <-done:
+		if len(got) == 0 {
+			t.Fatal("Solve([25,50,75,100,3,6], 952) returned no formulas, want at least one")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Solve([25,50,75,100,3,6], ...) did not finish within 10s")
+	}
+}
+
+func TestBinaryBoundsRejectsLargeNegativeBase(t *testing.T) {
+	base := mustValue(t, "-4782968") // 1 - 9*9*9*9*9*9*9, from the reviewed bug report
+	exp := mustValue(t, "2")
+	if binaryBounds(OpPow, base, exp) {
+		t.Error("binaryBounds(OpPow, -4782968, 2) = true, want false: large-magnitude negative base must be rejected")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+// ValueParser turns a number literal, written in a backend's own notation
+// (e.g. "3/4" for rationals, "0.75" for float64), into a Value of that backend.
+type ValueParser func(string) (Value, error)
+
+// valueBackends holds every registered Value implementation, keyed by name.
+var valueBackends = map[string]ValueParser{
+	"rational": newRationalFromString,
+}
+
+// currentBackend is the backend used by FromPolish, FromInfix and newIntNode to
+// construct leaf Values. It defaults to "rational" to match existing behavior.
+var currentBackend = "rational"
+
+// RegisterValueBackend makes a Value implementation selectable by name via
+// SetValueBackend. Only the leaf representation changes between backends; the
+// PerformUnary/PerformBinary/Even/Zero/etc. interface stays the same.
+func RegisterValueBackend(name string, parser ValueParser) {
+	valueBackends[name] = parser
+}
+
+// SetValueBackend selects which registered backend subsequent parsing uses.
+// It returns an error if name was never registered.
+func SetValueBackend(name string) error {
+	if _, ok := valueBackends[name]; !ok {
+		return fmt.Errorf("unknown value backend %q", name)
+	}
+	currentBackend = name
+	return nil
+}
+
+// parseValue parses s as a leaf value using the currently selected backend.
+func parseValue(s string) (Value, error) {
+	return valueBackends[currentBackend](s)
+}